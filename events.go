@@ -0,0 +1,160 @@
+package simdb
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"go.aporeto.io/elemental"
+)
+
+// EventOp identifies the kind of mutation an Event describes.
+type EventOp string
+
+const (
+	EventInsert EventOp = "insert"
+	EventUpdate EventOp = "update"
+	EventDelete EventOp = "delete"
+)
+
+// Event describes one committed mutation on an identity.
+type Event struct {
+	Op        EventOp                `json:"op"`
+	Identity  string                 `json:"identity"`
+	Before    map[string]interface{} `json:"before,omitempty"`
+	After     map[string]interface{} `json:"after,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// watcherBuffer is the size of the bounded, drop-oldest channel Watch
+// hands back to subscribers.
+const watcherBuffer = 64
+
+// OnInsert registers fn to run every time a record is successfully
+// inserted into entity's identity. fn receives the inserted record.
+func (d *Driver) OnInsert(entity elemental.Identifiable, fn func(entity map[string]interface{})) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	identity, err := d.identityFor(entity)
+	if err != nil {
+		return err
+	}
+	d.insertHooks[identity] = append(d.insertHooks[identity], fn)
+	return nil
+}
+
+// OnUpdate registers fn to run every time a record in entity's identity
+// is successfully updated. fn receives the record before and after the
+// update.
+func (d *Driver) OnUpdate(entity elemental.Identifiable, fn func(old, new map[string]interface{})) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	identity, err := d.identityFor(entity)
+	if err != nil {
+		return err
+	}
+	d.updateHooks[identity] = append(d.updateHooks[identity], fn)
+	return nil
+}
+
+// OnDelete registers fn to run every time a record is successfully
+// deleted from entity's identity. fn receives the deleted record.
+func (d *Driver) OnDelete(entity elemental.Identifiable, fn func(entity map[string]interface{})) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	identity, err := d.identityFor(entity)
+	if err != nil {
+		return err
+	}
+	d.deleteHooks[identity] = append(d.deleteHooks[identity], fn)
+	return nil
+}
+
+// Watch returns a channel that receives every mutation Event committed
+// against entity's identity. The channel has a bounded buffer; once
+// full, the oldest queued event is dropped to make room so a slow
+// subscriber never blocks a writer.
+func (d *Driver) Watch(entity elemental.Identifiable) (<-chan Event, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	identity, err := d.identityFor(entity)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan Event, watcherBuffer)
+	d.watchers[identity] = append(d.watchers[identity], ch)
+	return ch, nil
+}
+
+// EnableChangeLog makes the Driver append every mutation Event, across
+// all identities, as a JSON line to the file at path, so the log can be
+// tailed or replayed by another process.
+func (d *Driver) EnableChangeLog(path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.changeLog = f
+	return nil
+}
+
+// emit records evt for identity. On a plain Driver it dispatches
+// immediately. On a Tx, mutations aren't real until Commit, so it's
+// buffered instead: Commit dispatches every buffered event once the
+// write actually lands, and Rollback drops them untouched.
+func (d *Driver) emit(identity string, evt Event) {
+	evt.Identity = identity
+	evt.Timestamp = time.Now()
+
+	if d.pending != nil {
+		*d.pending = append(*d.pending, pendingEvent{identity: identity, evt: evt})
+		return
+	}
+	d.dispatch(identity, evt)
+}
+
+// dispatch notifies every hook and watcher registered on identity, and
+// appends to the change log if one is enabled. Callers must already
+// hold d.mutex, so subscribers see events in the order they committed.
+func (d *Driver) dispatch(identity string, evt Event) {
+	switch evt.Op {
+	case EventInsert:
+		for _, fn := range d.insertHooks[identity] {
+			fn(evt.After)
+		}
+	case EventUpdate:
+		for _, fn := range d.updateHooks[identity] {
+			fn(evt.Before, evt.After)
+		}
+	case EventDelete:
+		for _, fn := range d.deleteHooks[identity] {
+			fn(evt.Before)
+		}
+	}
+
+	for _, ch := range d.watchers[identity] {
+		select {
+		case ch <- evt:
+		default:
+			// Buffer's full: drop the oldest queued event to make room,
+			// then try once more. If a concurrent receive beat us to it,
+			// the second send just succeeds instead.
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- evt:
+			default:
+			}
+		}
+	}
+
+	if d.changeLog != nil {
+		if data, err := json.Marshal(evt); err == nil {
+			d.changeLog.Write(append(data, '\n'))
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package simdb
+
+import "testing"
+
+func TestOnInsertFiresWithInsertedRecord(t *testing.T) {
+	d := newTestDriver()
+	var got map[string]interface{}
+	if err := d.OnInsert(testCustomer{}, func(entity map[string]interface{}) {
+		got = entity
+	}); err != nil {
+		t.Fatalf("OnInsert: %v", err)
+	}
+
+	if err := d.Insert(testCustomer{ID: "c1", Name: "sarouje"}); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if got == nil || got["id"] != "c1" {
+		t.Fatalf("expected insert hook to fire with the inserted record, got %#v", got)
+	}
+}
+
+func TestTxBuffersEventsUntilCommit(t *testing.T) {
+	d := newTestDriver()
+	fired := 0
+	if err := d.OnInsert(testCustomer{}, func(entity map[string]interface{}) {
+		fired++
+	}); err != nil {
+		t.Fatalf("OnInsert: %v", err)
+	}
+
+	tx, err := d.Begin()
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if err := tx.Insert(testCustomer{ID: "c1", Name: "sarouje"}); err != nil {
+		t.Fatalf("tx.Insert: %v", err)
+	}
+	if fired != 0 {
+		t.Fatalf("expected the insert hook not to fire before Commit, fired %d times", fired)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if fired != 1 {
+		t.Fatalf("expected the insert hook to fire exactly once after Commit, fired %d times", fired)
+	}
+}
+
+func TestTxRollbackDiscardsEvents(t *testing.T) {
+	d := newTestDriver()
+	fired := 0
+	if err := d.OnInsert(testCustomer{}, func(entity map[string]interface{}) {
+		fired++
+	}); err != nil {
+		t.Fatalf("OnInsert: %v", err)
+	}
+
+	tx, err := d.Begin()
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if err := tx.Insert(testCustomer{ID: "c1", Name: "sarouje"}); err != nil {
+		t.Fatalf("tx.Insert: %v", err)
+	}
+	tx.Rollback()
+
+	if fired != 0 {
+		t.Fatalf("expected the insert hook never to fire after Rollback, fired %d times", fired)
+	}
+}
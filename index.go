@@ -0,0 +1,257 @@
+package simdb
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.aporeto.io/elemental"
+)
+
+// indexFile is the sidecar document persisted for a secondary index: it
+// maps a field's stringified value to the identifiers of every record
+// holding that value.
+type indexFile struct {
+	Deleted bool                `json:"deleted,omitempty"`
+	Unique  bool                `json:"unique"`
+	Values  map[string][]string `json:"values"`
+}
+
+func indexIdentity(identity, field string) string {
+	return fmt.Sprintf("%s_idx_%s", identity, field)
+}
+
+func (d *Driver) loadIndex(identity, field string) (indexFile, bool, error) {
+	data, err := d.storage.Read(indexIdentity(identity, field))
+	if err != nil {
+		if err == ErrRecordNotFound {
+			return indexFile{}, false, nil
+		}
+		return indexFile{}, false, err
+	}
+	var doc indexFile
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return indexFile{}, false, err
+	}
+	if doc.Deleted {
+		return indexFile{}, false, nil
+	}
+	return doc, true, nil
+}
+
+func (d *Driver) saveIndex(identity, field string, doc indexFile) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return d.storage.Write(indexIdentity(identity, field), data)
+}
+
+// buildIndex scans every record currently stored for identity and
+// (re)builds the value -> []id mapping for field.
+func (d *Driver) buildIndex(identity, field string, unique bool) error {
+	records, err := d.storage.List(identity)
+	if err != nil {
+		return err
+	}
+
+	values := map[string][]string{}
+	for _, record := range records {
+		id, ok := record[IdentifierKey]
+		if !ok {
+			continue
+		}
+		key := fmt.Sprintf("%v", record[field])
+		if unique && len(values[key]) > 0 {
+			return fmt.Errorf("simdb: cannot build unique index on %s.%s: duplicate value %q", identity, field, key)
+		}
+		values[key] = append(values[key], fmt.Sprintf("%v", id))
+	}
+
+	return d.saveIndex(identity, field, indexFile{Unique: unique, Values: values})
+}
+
+// validateUniqueIndexes checks that none of identity's registered unique
+// indexes would be violated by records, the full record set about to be
+// written. It reads the existing index sidecars but makes no changes to
+// storage or the indexes themselves, so Insert/Update can call it before
+// writing and reject the write instead of persisting a duplicate that
+// then locks the index up for good.
+func (d *Driver) validateUniqueIndexes(identity string, records []map[string]interface{}) error {
+	for _, field := range d.indexedFields[identity] {
+		doc, ok, err := d.loadIndex(identity, field)
+		if err != nil {
+			return err
+		}
+		if !ok || !doc.Unique {
+			continue
+		}
+
+		seen := make(map[string]bool, len(records))
+		for _, record := range records {
+			key := fmt.Sprintf("%v", record[field])
+			if seen[key] {
+				return fmt.Errorf("simdb: unique index violation on %s.%s: duplicate value %q", identity, field, key)
+			}
+			seen[key] = true
+		}
+	}
+	return nil
+}
+
+// reindexAll rebuilds every secondary index registered on identity. It's
+// called after a successful Insert/Update/Delete, with d.mutex already
+// held, so indexes never drift from what's on disk. Insert/Update must
+// call validateUniqueIndexes before writing, so by the time reindexAll
+// runs the data is already known to satisfy every unique index.
+func (d *Driver) reindexAll(identity string) error {
+	for _, field := range d.indexedFields[identity] {
+		doc, ok, err := d.loadIndex(identity, field)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := d.buildIndex(identity, field, doc.Unique); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnsureIndex builds (or rebuilds) a secondary index on entity's field,
+// persisted as a sidecar `<identity>_idx_<field>.json` mapping each
+// value to the identifiers of the records holding it. Once built, the
+// index is kept up to date by Insert/Update/Delete and is used by
+// Where()/Get() to avoid a full scan for "=" and "in" clauses on field.
+// If unique is true, EnsureIndex fails when field isn't actually unique
+// across entity's records.
+//
+//	err := driver.EnsureIndex(Customer{}, "custid", true)
+func (d *Driver) EnsureIndex(entity elemental.Identifiable, field string, unique bool) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	identity, err := d.identityFor(entity)
+	if err != nil {
+		return err
+	}
+	if err := d.buildIndex(identity, field, unique); err != nil {
+		return err
+	}
+
+	for _, f := range d.indexedFields[identity] {
+		if f == field {
+			return nil
+		}
+	}
+	d.indexedFields[identity] = append(d.indexedFields[identity], field)
+	return nil
+}
+
+// DropIndex removes the secondary index previously built by EnsureIndex
+// on entity's field. It's a no-op if no such index exists.
+func (d *Driver) DropIndex(entity elemental.Identifiable, field string) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	identity, err := d.identityFor(entity)
+	if err != nil {
+		return err
+	}
+	if err := d.saveIndex(identity, field, indexFile{Deleted: true}); err != nil {
+		return err
+	}
+
+	fields := d.indexedFields[identity]
+	for i, f := range fields {
+		if f == field {
+			d.indexedFields[identity] = append(fields[:i], fields[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Reindex rebuilds the secondary index on entity's field from the
+// records currently in storage, preserving its uniqueness setting. Use
+// it to repair an index after restoring storage from a backup, or after
+// writes made through a different Driver instance.
+func (d *Driver) Reindex(entity elemental.Identifiable, field string) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	identity, err := d.identityFor(entity)
+	if err != nil {
+		return err
+	}
+	doc, ok, err := d.loadIndex(identity, field)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("simdb: no index on %s.%s to rebuild", identity, field)
+	}
+	return d.buildIndex(identity, field, doc.Unique)
+}
+
+// applyIndexPrefilter narrows d.originalJSON down to the candidate
+// records resolvable via a secondary index, when the current query is a
+// single conjunction with at least one "=" or "in" clause on an indexed
+// field. processQuery still evaluates every clause against the narrowed
+// set, so this never changes the result, only how many records get
+// scanned to produce it; it falls back to a full scan whenever no
+// indexed clause is usable.
+func (d *Driver) applyIndexPrefilter() {
+	if len(d.queries) != 1 {
+		return // an OR across groups has no single candidate set to narrow to
+	}
+
+	identity, err := d.getEntityName()
+	if err != nil {
+		return
+	}
+
+	for _, q := range d.queries[0] {
+		if q.operator != "=" && q.operator != "in" {
+			continue
+		}
+		doc, ok, err := d.loadIndex(identity, q.key)
+		if err != nil || !ok {
+			continue
+		}
+
+		ids := map[string]bool{}
+		switch q.operator {
+		case "=":
+			for _, id := range doc.Values[fmt.Sprintf("%v", q.value)] {
+				ids[id] = true
+			}
+		case "in":
+			values, ok := q.value.([]interface{})
+			if !ok {
+				continue
+			}
+			for _, v := range values {
+				for _, id := range doc.Values[fmt.Sprintf("%v", v)] {
+					ids[id] = true
+				}
+			}
+		}
+
+		db, ok := d.originalJSON.([]interface{})
+		if !ok {
+			return
+		}
+		candidates := make([]interface{}, 0, len(ids))
+		for _, item := range db {
+			if record, ok := item.(map[string]interface{}); ok {
+				if v, ok := record[IdentifierKey]; ok && ids[fmt.Sprintf("%v", v)] {
+					candidates = append(candidates, item)
+				}
+			}
+		}
+		d.originalJSON = candidates
+		return // one usable indexed clause is enough to narrow the scan
+	}
+}
@@ -0,0 +1,51 @@
+package simdb
+
+import "testing"
+
+func TestEnsureIndexRejectsDuplicateWithoutPersisting(t *testing.T) {
+	d := newTestDriver()
+	if err := d.Insert(testCustomer{ID: "c1", Name: "sarouje"}); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if err := d.EnsureIndex(testCustomer{}, "name", true); err != nil {
+		t.Fatalf("EnsureIndex: %v", err)
+	}
+
+	if err := d.Insert(testCustomer{ID: "c2", Name: "sarouje"}); err == nil {
+		t.Fatalf("expected a unique index violation error")
+	}
+
+	var out []testCustomer
+	if err := d.Open(testCustomer{}).Get().AsEntity(&out); err != nil {
+		t.Fatalf("AsEntity: %v", err)
+	}
+	if len(out) != 1 {
+		t.Fatalf("expected the rejected duplicate to not be persisted, got %d records", len(out))
+	}
+
+	// A later, unrelated insert must still succeed: the earlier rejected
+	// write must not have left the index permanently broken.
+	if err := d.Insert(testCustomer{ID: "c3", Name: "someone else"}); err != nil {
+		t.Fatalf("Insert after rejected duplicate: %v", err)
+	}
+}
+
+func TestWhereUsesIndexForEqualsClause(t *testing.T) {
+	d := newTestDriver()
+	for i, name := range []string{"a", "b", "c"} {
+		if err := d.Insert(testCustomer{ID: string(rune('1' + i)), Name: name}); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+	if err := d.EnsureIndex(testCustomer{}, "name", true); err != nil {
+		t.Fatalf("EnsureIndex: %v", err)
+	}
+
+	var out testCustomer
+	if err := d.Open(testCustomer{}).Where("name", "=", "b").First().AsEntity(&out); err != nil {
+		t.Fatalf("AsEntity: %v", err)
+	}
+	if out.Name != "b" {
+		t.Fatalf("expected 'b', got %q", out.Name)
+	}
+}
@@ -0,0 +1,154 @@
+package simdb
+
+import "fmt"
+
+// QueryFunc evaluates whether a record's value for a where clause's key
+// satisfies that clause against value.
+type QueryFunc func(key string, input, value interface{}) (bool, error)
+
+// loadDefaultQueryMap returns the built-in set of operators Where()
+// supports.
+func loadDefaultQueryMap() map[string]QueryFunc {
+	return map[string]QueryFunc{
+		"=":  equals,
+		"!=": notEquals,
+		">":  greaterThan,
+		">=": greaterThanOrEqual,
+		"<":  lessThan,
+		"<=": lessThanOrEqual,
+		"in": in,
+	}
+}
+
+func equals(key string, input, value interface{}) (bool, error) {
+	return fmt.Sprintf("%v", input) == fmt.Sprintf("%v", value), nil
+}
+
+func notEquals(key string, input, value interface{}) (bool, error) {
+	ok, err := equals(key, input, value)
+	return !ok, err
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func greaterThan(key string, input, value interface{}) (bool, error) {
+	a, b, err := comparableFloats(key, input, value)
+	if err != nil {
+		return false, err
+	}
+	return a > b, nil
+}
+
+func greaterThanOrEqual(key string, input, value interface{}) (bool, error) {
+	a, b, err := comparableFloats(key, input, value)
+	if err != nil {
+		return false, err
+	}
+	return a >= b, nil
+}
+
+func lessThan(key string, input, value interface{}) (bool, error) {
+	a, b, err := comparableFloats(key, input, value)
+	if err != nil {
+		return false, err
+	}
+	return a < b, nil
+}
+
+func lessThanOrEqual(key string, input, value interface{}) (bool, error) {
+	a, b, err := comparableFloats(key, input, value)
+	if err != nil {
+		return false, err
+	}
+	return a <= b, nil
+}
+
+func comparableFloats(key string, input, value interface{}) (float64, float64, error) {
+	a, aok := toFloat(input)
+	b, bok := toFloat(value)
+	if !aok || !bok {
+		return 0, 0, fmt.Errorf("simdb: %q is not numerically comparable", key)
+	}
+	return a, b, nil
+}
+
+func in(key string, input, value interface{}) (bool, error) {
+	values, ok := value.([]interface{})
+	if !ok {
+		return false, fmt.Errorf("simdb: value for \"in\" on %q must be a slice", key)
+	}
+	for _, v := range values {
+		if ok, _ := equals(key, input, v); ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// matchesGroup reports whether record satisfies every clause in group
+// (an "AND" conjunction).
+func (d *Driver) matchesGroup(record map[string]interface{}, group []query) (bool, error) {
+	for _, q := range group {
+		fn, ok := d.queryMap[q.operator]
+		if !ok {
+			return false, fmt.Errorf("simdb: unsupported operator %q", q.operator)
+		}
+		matched, err := fn(q.key, record[q.key], q.value)
+		if err != nil {
+			return false, err
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// processQuery filters d.originalJSON against d.queries, an "OR" of
+// "AND" groups built up by Where(), and stores the result in
+// d.jsonContent.
+func (d *Driver) processQuery() {
+	db, ok := d.originalJSON.([]interface{})
+	if !ok {
+		d.jsonContent = d.originalJSON
+		return
+	}
+
+	matched := make([]interface{}, 0, len(db))
+	for _, item := range db {
+		record, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		isMatch := false
+		for _, group := range d.queries {
+			groupMatched, err := d.matchesGroup(record, group)
+			if err != nil {
+				d.addError(err)
+				continue
+			}
+			if groupMatched {
+				isMatch = true
+				break
+			}
+		}
+		if isMatch {
+			matched = append(matched, item)
+		}
+	}
+
+	d.jsonContent = matched
+}
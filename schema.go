@@ -0,0 +1,342 @@
+package simdb
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"go.aporeto.io/elemental"
+)
+
+// fieldTag holds the parsed `simdb:"..."` struct tag options for a field.
+type fieldTag struct {
+	defaultValue string
+	renameFrom   string
+}
+
+func parseFieldTag(tag string) fieldTag {
+	var ft fieldTag
+	if tag == "" {
+		return ft
+	}
+	for _, part := range strings.Split(tag, ",") {
+		switch {
+		case strings.HasPrefix(part, "default="):
+			ft.defaultValue = strings.TrimPrefix(part, "default=")
+		case strings.HasPrefix(part, "rename="):
+			ft.renameFrom = strings.TrimPrefix(part, "rename=")
+		}
+	}
+	return ft
+}
+
+// structField describes one field of an entity struct as seen by Sync.
+type structField struct {
+	name string
+	kind string
+	tag  fieldTag
+}
+
+// structFields reflects over entity and returns its exported fields, using
+// the json tag name when present so field names line up with what's
+// actually stored on disk.
+func structFields(entity elemental.Identifiable) []structField {
+	t := reflect.TypeOf(entity)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	fields := make([]structField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue // unexported
+		}
+
+		name := sf.Name
+		if jsonTag := sf.Tag.Get("json"); jsonTag != "" {
+			if n := strings.Split(jsonTag, ",")[0]; n != "" && n != "-" {
+				name = n
+			}
+		}
+
+		fields = append(fields, structField{
+			name: name,
+			kind: sf.Type.Kind().String(),
+			tag:  parseFieldTag(sf.Tag.Get("simdb")),
+		})
+	}
+	return fields
+}
+
+// fieldSchema is one field of the sidecar schema recorded for an entity.
+type fieldSchema struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// entitySchema is the sidecar document persisted for a synced entity,
+// recording its field set as of the last Sync.
+type entitySchema struct {
+	Fields []fieldSchema `json:"fields"`
+}
+
+func schemaIdentity(identity string) string {
+	return identity + "_schema"
+}
+
+func (d *Driver) loadSchema(identity string) (entitySchema, bool, error) {
+	data, err := d.storage.Read(schemaIdentity(identity))
+	if err != nil {
+		if err == ErrRecordNotFound {
+			return entitySchema{}, false, nil
+		}
+		return entitySchema{}, false, err
+	}
+	var s entitySchema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return entitySchema{}, false, err
+	}
+	return s, true, nil
+}
+
+func (d *Driver) saveSchema(identity string, fields []structField) error {
+	s := entitySchema{Fields: make([]fieldSchema, 0, len(fields))}
+	for _, f := range fields {
+		s.Fields = append(s.Fields, fieldSchema{Name: f.name, Type: f.kind})
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return d.storage.Write(schemaIdentity(identity), data)
+}
+
+// SchemaDiff describes the migration Sync plans to apply (or, outside
+// DryRun, already applied) for one entity.
+type SchemaDiff struct {
+	Identity      string            `json:"identity"`
+	FieldsAdded   []string          `json:"fieldsAdded"`
+	FieldsRemoved []string          `json:"fieldsRemoved"`
+	FieldsRenamed map[string]string `json:"fieldsRenamed"` // new name -> old name
+}
+
+func (d *Driver) identityFor(entity elemental.Identifiable) (string, error) {
+	prevEntity := d.entityDealingWith
+	d.entityDealingWith = entity.Identity()
+	identity, err := d.getEntityName()
+	d.entityDealingWith = prevEntity
+	return identity, err
+}
+
+// coerceDefault converts a `simdb:"default=..."` tag's raw string value
+// into the JSON-equivalent type for kind (e.g. "0" -> the number 0 for
+// an int field, "true" -> the bool true), so a backfilled field decodes
+// the same way a real value of that field's type would. It falls back
+// to the raw string when kind isn't one it recognizes or raw doesn't
+// parse as that kind.
+func coerceDefault(kind, raw string) interface{} {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"):
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return n
+		}
+	case strings.HasPrefix(kind, "float"):
+		if f, err := strconv.ParseFloat(raw, 64); err == nil {
+			return f
+		}
+	case kind == "bool":
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	}
+	return raw
+}
+
+func diffFields(prev entitySchema, curr []structField) SchemaDiff {
+	diff := SchemaDiff{FieldsRenamed: map[string]string{}}
+
+	prevNames := make(map[string]bool, len(prev.Fields))
+	for _, f := range prev.Fields {
+		prevNames[f.Name] = true
+	}
+
+	currNames := make(map[string]bool, len(curr))
+	for _, f := range curr {
+		currNames[f.name] = true
+		if prevNames[f.name] {
+			continue
+		}
+		if f.tag.renameFrom != "" && prevNames[f.tag.renameFrom] {
+			diff.FieldsRenamed[f.name] = f.tag.renameFrom
+		} else {
+			diff.FieldsAdded = append(diff.FieldsAdded, f.name)
+		}
+	}
+
+	renamedAway := make(map[string]bool, len(diff.FieldsRenamed))
+	for _, old := range diff.FieldsRenamed {
+		renamedAway[old] = true
+	}
+	for name := range prevNames {
+		if !currNames[name] && !renamedAway[name] {
+			diff.FieldsRemoved = append(diff.FieldsRemoved, name)
+		}
+	}
+
+	return diff
+}
+
+// applyDiff rewrites every stored record for identity according to diff:
+// renamed fields are copied from their old key, added fields are
+// backfilled with their `simdb:"default=..."` value (or else the zero
+// value of a string, bool or numeric field, and nil for any other kind),
+// and removed fields are pruned.
+func (d *Driver) applyDiff(identity string, fields []structField, diff SchemaDiff) error {
+	records, err := d.storage.List(identity)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	byName := make(map[string]structField, len(fields))
+	for _, f := range fields {
+		byName[f.name] = f
+	}
+
+	for _, record := range records {
+		for newName, oldName := range diff.FieldsRenamed {
+			if v, ok := record[oldName]; ok {
+				record[newName] = v
+			}
+			delete(record, oldName)
+		}
+		for _, name := range diff.FieldsAdded {
+			if _, ok := record[name]; ok {
+				continue
+			}
+			f := byName[name]
+			switch {
+			case f.tag.defaultValue != "":
+				record[name] = coerceDefault(f.kind, f.tag.defaultValue)
+			case f.kind == "string":
+				record[name] = ""
+			case f.kind == "bool":
+				record[name] = false
+			case strings.HasPrefix(f.kind, "int"), strings.HasPrefix(f.kind, "uint"), strings.HasPrefix(f.kind, "float"):
+				record[name] = 0
+			default:
+				record[name] = nil
+			}
+		}
+		for _, name := range diff.FieldsRemoved {
+			delete(record, name)
+		}
+	}
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	if err := d.storage.Write(identity, data); err != nil {
+		return err
+	}
+	return d.reindexAll(identity)
+}
+
+// Sync inspects each entity via reflection, compares its current field
+// set against the sidecar schema recorded by the previous Sync, and
+// migrates every stored record accordingly: newly added fields are
+// backfilled with their zero value (nil for fields that aren't a string,
+// bool or number) or a `simdb:"default=..."` tag value, fields declared `simdb:"rename=oldName"` are copied from their
+// old key and the old key removed, and fields no longer on the struct
+// are pruned.
+//
+//	err := driver.Sync(Customer{}, Order{})
+func (d *Driver) Sync(entities ...elemental.Identifiable) error {
+	_, err := d.sync(entities, false)
+	return err
+}
+
+// SyncDryRun behaves like Sync but only computes and returns the planned
+// diff for each entity, without touching any stored record or the
+// sidecar schema.
+func (d *Driver) SyncDryRun(entities ...elemental.Identifiable) ([]SchemaDiff, error) {
+	return d.sync(entities, true)
+}
+
+func (d *Driver) sync(entities []elemental.Identifiable, dryRun bool) ([]SchemaDiff, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	diffs := make([]SchemaDiff, 0, len(entities))
+	for _, entity := range entities {
+		identity, err := d.identityFor(entity)
+		if err != nil {
+			return nil, err
+		}
+
+		fields := structFields(entity)
+		prev, hadSchema, err := d.loadSchema(identity)
+		if err != nil {
+			return nil, err
+		}
+
+		diff := diffFields(prev, fields)
+		diff.Identity = identity
+
+		if !dryRun {
+			if hadSchema {
+				if err := d.applyDiff(identity, fields, diff); err != nil {
+					return nil, err
+				}
+			}
+			if err := d.saveSchema(identity, fields); err != nil {
+				return nil, err
+			}
+		}
+
+		diffs = append(diffs, diff)
+	}
+	return diffs, nil
+}
+
+// Migrate applies fn to every record currently stored for entity's
+// identity, rewriting each one in place. Use it for custom transforms
+// that Sync's automatic add/rename/remove migration can't express.
+//
+//	err := driver.Migrate(Customer{}, func(record map[string]interface{}) error {
+//		record["fullName"] = fmt.Sprintf("%v %v", record["firstName"], record["lastName"])
+//		return nil
+//	})
+func (d *Driver) Migrate(entity elemental.Identifiable, fn func(map[string]interface{}) error) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	identity, err := d.identityFor(entity)
+	if err != nil {
+		return err
+	}
+
+	records, err := d.storage.List(identity)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	if err := d.storage.Write(identity, data); err != nil {
+		return err
+	}
+	return d.reindexAll(identity)
+}
@@ -0,0 +1,89 @@
+package simdb
+
+import (
+	"testing"
+
+	"go.aporeto.io/elemental"
+)
+
+type testCustomerV2 struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Credit int    `json:"credit" simdb:"default=0"`
+}
+
+func (c testCustomerV2) Identity() elemental.Identity {
+	return testCustomer{}.Identity()
+}
+
+func (c testCustomerV2) Identifier() string      { return c.ID }
+func (c testCustomerV2) SetIdentifier(id string) { c.ID = id }
+func (c testCustomerV2) Version() int            { return 1 }
+
+func TestSyncBackfillsDefaultWithCoercedType(t *testing.T) {
+	d := newTestDriver()
+	if err := d.Insert(testCustomer{ID: "c1", Name: "sarouje"}); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if err := d.Sync(testCustomer{}); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if err := d.Sync(testCustomerV2{}); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	var out []testCustomerV2
+	if err := d.Open(testCustomerV2{}).Get().AsEntity(&out); err != nil {
+		t.Fatalf("AsEntity: %v", err)
+	}
+	if len(out) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(out))
+	}
+	if out[0].Credit != 0 {
+		t.Fatalf("expected backfilled Credit to decode as 0, got %d", out[0].Credit)
+	}
+}
+
+func TestSyncReindexesAfterMigration(t *testing.T) {
+	d := newTestDriver()
+	if err := d.Insert(testCustomer{ID: "c1", Name: "sarouje"}); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if err := d.Sync(testCustomer{}); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if err := d.EnsureIndex(testCustomer{}, "name", true); err != nil {
+		t.Fatalf("EnsureIndex: %v", err)
+	}
+
+	if err := d.Sync(testCustomerV2{}); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	var out testCustomer
+	if err := d.Open(testCustomer{}).Where("name", "=", "sarouje").First().AsEntity(&out); err != nil {
+		t.Fatalf("expected the index to still find the record after Sync: %v", err)
+	}
+}
+
+func TestMigrateReindexesAfterRewrite(t *testing.T) {
+	d := newTestDriver()
+	if err := d.Insert(testCustomer{ID: "c1", Name: "sarouje"}); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if err := d.EnsureIndex(testCustomer{}, "name", true); err != nil {
+		t.Fatalf("EnsureIndex: %v", err)
+	}
+
+	if err := d.Migrate(testCustomer{}, func(record map[string]interface{}) error {
+		record["name"] = "renamed"
+		return nil
+	}); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	var out testCustomer
+	if err := d.Open(testCustomer{}).Where("name", "=", "renamed").First().AsEntity(&out); err != nil {
+		t.Fatalf("expected the index to reflect the migrated value: %v", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"go.aporeto.io/elemental"
+	"os"
 	"sync"
 )
 
@@ -23,7 +24,7 @@ type query struct {
 
 // Driver contains all the state of the db.
 type Driver struct {
-	dir               string    //directory name to store the db
+	storage           Storage   // persistence backend, e.g. FileStore, MemoryStore
 	queries           [][]query // nested queries
 	queryIndex        int
 	queryMap          map[string]QueryFunc // contains query functions
@@ -33,20 +34,45 @@ type Driver struct {
 	isOpened          bool
 	entityDealingWith elemental.Identity
 	mutex             *sync.Mutex
+	indexedFields     map[string][]string // identity -> fields with a secondary index, set by EnsureIndex
+	insertHooks       map[string][]func(entity map[string]interface{})
+	updateHooks       map[string][]func(old, new map[string]interface{})
+	deleteHooks       map[string][]func(entity map[string]interface{})
+	watchers          map[string][]chan Event
+	changeLog         *os.File
+	pending           *[]pendingEvent // non-nil on a Tx's inner Driver: buffers emit() until Commit
 }
 
-// New creates a new database driver. Accepts the directory name to store the db files.
+// New creates a new database driver backed by the default directory-based
+// JSON store. Accepts the directory name to store the db files.
 // If the passed directory not exist then will create one.
 //
 //	driver, err:=db.New("customer")
 func New(dir string) (*Driver, error) {
-	driver := &Driver{
-		dir:      dir,
-		queryMap: loadDefaultQueryMap(),
-		mutex:    &sync.Mutex{},
+	store, err := NewFileStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithStorage(store), nil
+}
+
+// NewWithStorage creates a new database driver backed by any Storage
+// implementation, letting callers plug in alternatives to the default
+// directory-based JSON store, e.g. MemoryStore for tests or BoltStore/
+// RedisStore for single-file or clustered deployments.
+//
+//	driver := db.NewWithStorage(db.NewMemoryStore())
+func NewWithStorage(store Storage) *Driver {
+	return &Driver{
+		storage:       store,
+		queryMap:      loadDefaultQueryMap(),
+		mutex:         &sync.Mutex{},
+		indexedFields: map[string][]string{},
+		insertHooks:   map[string][]func(entity map[string]interface{}){},
+		updateHooks:   map[string][]func(old, new map[string]interface{}){},
+		deleteHooks:   map[string][]func(entity map[string]interface{}){},
+		watchers:      map[string][]chan Event{},
 	}
-	err := createDirIfNotExist(dir)
-	return driver, err
 }
 
 // Open will open the json db based on the entity passed.
@@ -58,13 +84,24 @@ func New(dir string) (*Driver, error) {
 func (d *Driver) Open(entity elemental.Identifiable) *Driver {
 	d.queries = nil
 	d.entityDealingWith = entity.Identity()
-	db, err := d.openDB(entity)
-	d.originalJSON = db
-	d.jsonContent = d.originalJSON
 	d.isOpened = true
+
+	identity, err := d.getEntityName()
+	if err != nil {
+		d.addError(err)
+		return d
+	}
+
+	records, err := d.storage.List(identity)
 	if err != nil {
 		d.addError(err)
 	}
+	db := make([]interface{}, len(records))
+	for i, record := range records {
+		db[i] = record
+	}
+	d.originalJSON = db
+	d.jsonContent = d.originalJSON
 	return d
 }
 
@@ -92,8 +129,42 @@ func (d *Driver) Insert(entity elemental.Identifiable) (err error) {
 	defer d.mutex.Unlock()
 
 	d.entityDealingWith = entity.Identity()
-	err = d.readAppend(entity)
-	return
+	identity, err := d.getEntityName()
+	if err != nil {
+		return err
+	}
+
+	records, err := d.storage.List(identity)
+	if err != nil {
+		return err
+	}
+
+	entByte, err := json.Marshal(entity)
+	if err != nil {
+		return err
+	}
+	var record map[string]interface{}
+	if err = json.Unmarshal(entByte, &record); err != nil {
+		return err
+	}
+	records = append(records, record)
+
+	if err = d.validateUniqueIndexes(identity, records); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	if err = d.storage.Write(identity, data); err != nil {
+		return err
+	}
+	if err = d.reindexAll(identity); err != nil {
+		return err
+	}
+	d.emit(identity, Event{Op: EventInsert, After: record})
+	return nil
 }
 
 // Where builds a where clause to filter the records.
@@ -130,6 +201,7 @@ func (d *Driver) Get() *Driver {
 		return d
 	}
 	if len(d.queries) > 0 {
+		d.applyIndexPrefilter()
 		d.processQuery()
 	} else {
 		d.jsonContent = d.originalJSON
@@ -221,29 +293,63 @@ func (d *Driver) Update(entity elemental.Identifiable) (err error) {
 	d.entityDealingWith = entity.Identity()
 	entityID := entity.Identifier()
 	couldUpdate := false
-	// entName, _ := d.getEntityName()
+	var before map[string]interface{}
 
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
+
+	entByte, err := json.Marshal(entity)
+	if err != nil {
+		return err
+	}
+	var after map[string]interface{}
+	if err = json.Unmarshal(entByte, &after); err != nil {
+		return err
+	}
+
 	records := d.Open(entity).Get().RawArray()
+	recordMaps := make([]map[string]interface{}, 0, len(records))
 
 	if len(records) > 0 {
 		for indx, item := range records {
 			if record, ok := item.(map[string]interface{}); ok {
 				if v, ok := record[IdentifierKey]; ok && fmt.Sprintf("%v", v) == fmt.Sprintf("%v", entityID) {
+					before = record
 					records[indx] = entity
 					couldUpdate = true
+					recordMaps = append(recordMaps, after)
+				} else {
+					recordMaps = append(recordMaps, record)
 				}
 			}
 		}
 	}
-	if couldUpdate {
-		err = d.writeAll(records)
-	} else {
-		err = ErrUpdateFailed
+	if !couldUpdate {
+		return ErrUpdateFailed
 	}
 
-	return
+	identity, err := d.getEntityName()
+	if err != nil {
+		return err
+	}
+
+	if err = d.validateUniqueIndexes(identity, recordMaps); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	if err = d.storage.Write(identity, data); err != nil {
+		return err
+	}
+	if err = d.reindexAll(identity); err != nil {
+		return err
+	}
+
+	d.emit(identity, Event{Op: EventUpdate, Before: before, After: after})
+	return nil
 }
 
 // Upsert function will try updating the passed entity. If no records to update then
@@ -276,31 +382,35 @@ func (d *Driver) Upsert(entity elemental.Identifiable) (err error) {
 func (d *Driver) Delete(entity elemental.Identifiable) (err error) {
 	d.queries = nil
 	d.entityDealingWith = entity.Identity()
-	entityID := entity.Identifier()
-	entName, _ := d.getEntityName()
-	couldDelete := false
-	newRecordArray := make([]interface{}, 0, 0)
+	entityID := fmt.Sprintf("%v", entity.Identifier())
 
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
-	records := d.Open(entity).Get().RawArray()
 
-	if len(records) > 0 {
-		for indx, item := range records {
-			if record, ok := item.(map[string]interface{}); ok {
-				if v, ok := record[IdentifierKey]; ok && v != entityID {
-					records[indx] = entity
-					newRecordArray = append(newRecordArray, record)
-				} else {
-					couldDelete = true
-				}
+	identity, err := d.getEntityName()
+	if err != nil {
+		return err
+	}
+
+	var before map[string]interface{}
+	if records, lerr := d.storage.List(identity); lerr == nil {
+		for _, record := range records {
+			if v, ok := record[IdentifierKey]; ok && fmt.Sprintf("%v", v) == entityID {
+				before = record
+				break
 			}
 		}
 	}
-	if couldDelete {
-		err = d.writeAll(newRecordArray)
-	} else {
-		err = fmt.Errorf("failed to delete, unable to find any %s record with %s %s", entName, IdentifierKey, entityID)
+
+	if err = d.storage.Delete(identity, entityID); err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return fmt.Errorf("failed to delete, unable to find any %s record with %s %s", identity, IdentifierKey, entityID)
+		}
+		return err
 	}
-	return
+	if err = d.reindexAll(identity); err != nil {
+		return err
+	}
+	d.emit(identity, Event{Op: EventDelete, Before: before})
+	return nil
 }
@@ -0,0 +1,96 @@
+package simdb
+
+import (
+	"testing"
+
+	"go.aporeto.io/elemental"
+)
+
+// testCustomer is a minimal elemental.Identifiable fixture used across
+// this package's tests.
+type testCustomer struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Age  int    `json:"age,omitempty"`
+}
+
+func (c testCustomer) Identity() elemental.Identity {
+	return elemental.MakeIdentity("testcustomer", "testcustomers")
+}
+
+func (c testCustomer) Identifier() string      { return c.ID }
+func (c testCustomer) SetIdentifier(id string) { c.ID = id }
+func (c testCustomer) Version() int            { return 1 }
+
+func newTestDriver() *Driver {
+	return NewWithStorage(NewMemoryStore())
+}
+
+func TestInsertAndGet(t *testing.T) {
+	d := newTestDriver()
+
+	if err := d.Insert(testCustomer{ID: "c1", Name: "sarouje"}); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if err := d.Insert(testCustomer{ID: "c2", Name: "someone else"}); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	var out []testCustomer
+	if err := d.Open(testCustomer{}).Get().AsEntity(&out); err != nil {
+		t.Fatalf("AsEntity: %v", err)
+	}
+	if len(out) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(out))
+	}
+}
+
+func TestWhereFiltersRecords(t *testing.T) {
+	d := newTestDriver()
+	if err := d.Insert(testCustomer{ID: "c1", Name: "sarouje"}); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if err := d.Insert(testCustomer{ID: "c2", Name: "someone else"}); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	var out testCustomer
+	if err := d.Open(testCustomer{}).Where("id", "=", "c2").First().AsEntity(&out); err != nil {
+		t.Fatalf("AsEntity: %v", err)
+	}
+	if out.Name != "someone else" {
+		t.Fatalf("expected 'someone else', got %q", out.Name)
+	}
+}
+
+func TestUpdateAndDelete(t *testing.T) {
+	d := newTestDriver()
+	if err := d.Insert(testCustomer{ID: "c1", Name: "sarouje"}); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	if err := d.Update(testCustomer{ID: "c1", Name: "renamed"}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	var out testCustomer
+	if err := d.Open(testCustomer{}).Where("id", "=", "c1").First().AsEntity(&out); err != nil {
+		t.Fatalf("AsEntity: %v", err)
+	}
+	if out.Name != "renamed" {
+		t.Fatalf("expected 'renamed', got %q", out.Name)
+	}
+
+	if err := d.Delete(testCustomer{ID: "c1"}); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := d.Open(testCustomer{}).Where("id", "=", "c1").First().AsEntity(&out); err == nil {
+		t.Fatalf("expected error after deleting the only match")
+	}
+}
+
+func TestUpdateMissingRecordFails(t *testing.T) {
+	d := newTestDriver()
+	if err := d.Update(testCustomer{ID: "missing"}); err != ErrUpdateFailed {
+		t.Fatalf("expected ErrUpdateFailed, got %v", err)
+	}
+}
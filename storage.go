@@ -0,0 +1,126 @@
+package simdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Storage abstracts the persistence layer used by Driver, so that the
+// directory-based JSON store is just one of several possible backends.
+// Identities are always addressed by their string name (the same name
+// returned by Driver.getEntityName), and records are always plain
+// map[string]interface{} decoded from JSON.
+type Storage interface {
+	// Read returns the raw JSON last written for identity. It returns
+	// ErrRecordNotFound if nothing has been written for identity yet.
+	Read(identity string) ([]byte, error)
+	// Write persists data as the full set of records for identity,
+	// replacing whatever was stored before.
+	Write(identity string, data []byte) error
+	// List returns the decoded records stored for identity. It returns
+	// a nil slice, not an error, when identity has no records yet.
+	List(identity string) ([]map[string]interface{}, error)
+	// Delete removes the record identified by id from identity's
+	// records. It returns ErrRecordNotFound if id does not exist.
+	Delete(identity, id string) error
+}
+
+// FileStore is the default Storage implementation. It persists each
+// identity as its own JSON array file inside dir, the same layout simdb
+// has always used.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a FileStore rooted at dir, creating dir if it
+// doesn't already exist.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := createDirIfNotExist(dir); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (f *FileStore) path(identity string) string {
+	return filepath.Join(f.dir, identity+".json")
+}
+
+// Read implements Storage.
+func (f *FileStore) Read(identity string) ([]byte, error) {
+	data, err := ioutil.ReadFile(f.path(identity))
+	if os.IsNotExist(err) {
+		return nil, ErrRecordNotFound
+	}
+	return data, err
+}
+
+// Write implements Storage. It writes to a temp file in dir and renames
+// it into place, so a reader never observes a partially written file.
+func (f *FileStore) Write(identity string, data []byte) error {
+	path := f.path(identity)
+	tmp, err := ioutil.TempFile(f.dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// List implements Storage.
+func (f *FileStore) List(identity string) ([]map[string]interface{}, error) {
+	data, err := f.Read(identity)
+	if err != nil {
+		if err == ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var records []map[string]interface{}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Delete implements Storage.
+func (f *FileStore) Delete(identity, id string) error {
+	records, err := f.List(identity)
+	if err != nil {
+		return err
+	}
+	filtered, found := removeRecord(records, id)
+	if !found {
+		return ErrRecordNotFound
+	}
+	data, err := json.Marshal(filtered)
+	if err != nil {
+		return err
+	}
+	return f.Write(identity, data)
+}
+
+// removeRecord returns records with the entry whose IdentifierKey equals
+// id removed. The bool result reports whether such an entry existed.
+func removeRecord(records []map[string]interface{}, id string) ([]map[string]interface{}, bool) {
+	filtered := make([]map[string]interface{}, 0, len(records))
+	found := false
+	for _, r := range records {
+		if v, ok := r[IdentifierKey]; ok && fmt.Sprintf("%v", v) == id {
+			found = true
+			continue
+		}
+		filtered = append(filtered, r)
+	}
+	return filtered, found
+}
@@ -0,0 +1,90 @@
+package simdb
+
+import (
+	"encoding/json"
+
+	"go.etcd.io/bbolt"
+)
+
+// BoltStore persists every identity's records as a JSON blob under one
+// key in a single bbolt bucket, so the whole database lives in one file.
+type BoltStore struct {
+	db     *bbolt.DB
+	bucket []byte
+}
+
+// NewBoltStore opens (creating if necessary) a single-file bbolt database
+// at path and stores every identity's records as a key inside bucket.
+func NewBoltStore(path, bucket string) (*BoltStore, error) {
+	db, err := bbolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+	b := []byte(bucket)
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(b)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return &BoltStore{db: db, bucket: b}, nil
+}
+
+// Read implements Storage.
+func (b *BoltStore) Read(identity string) ([]byte, error) {
+	var data []byte
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket(b.bucket).Get([]byte(identity))
+		if v == nil {
+			return ErrRecordNotFound
+		}
+		data = append([]byte(nil), v...)
+		return nil
+	})
+	return data, err
+}
+
+// Write implements Storage.
+func (b *BoltStore) Write(identity string, data []byte) error {
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(b.bucket).Put([]byte(identity), data)
+	})
+}
+
+// List implements Storage.
+func (b *BoltStore) List(identity string) ([]map[string]interface{}, error) {
+	data, err := b.Read(identity)
+	if err != nil {
+		if err == ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var records []map[string]interface{}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Delete implements Storage.
+func (b *BoltStore) Delete(identity, id string) error {
+	records, err := b.List(identity)
+	if err != nil {
+		return err
+	}
+	filtered, found := removeRecord(records, id)
+	if !found {
+		return ErrRecordNotFound
+	}
+	data, err := json.Marshal(filtered)
+	if err != nil {
+		return err
+	}
+	return b.Write(identity, data)
+}
+
+// Close closes the underlying bbolt database.
+func (b *BoltStore) Close() error {
+	return b.db.Close()
+}
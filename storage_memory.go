@@ -0,0 +1,80 @@
+package simdb
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// MemoryStore is an in-memory Storage implementation. It's mainly meant
+// for unit tests that want Driver's semantics without touching disk.
+//
+// Like FileStore/BoltStore/RedisStore, it stores whatever bytes it's
+// given for an identity and only decodes into records where the Storage
+// contract requires records (List/Delete). Schema and index sidecars
+// persist non-array JSON documents through the same Write, so it must
+// not assume every payload is a record array.
+type MemoryStore struct {
+	mutex sync.RWMutex
+	data  map[string][]byte
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: map[string][]byte{}}
+}
+
+// Read implements Storage.
+func (m *MemoryStore) Read(identity string) ([]byte, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	data, ok := m.data[identity]
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+	out := make([]byte, len(data))
+	copy(out, data)
+	return out, nil
+}
+
+// Write implements Storage.
+func (m *MemoryStore) Write(identity string, data []byte) error {
+	stored := make([]byte, len(data))
+	copy(stored, data)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.data[identity] = stored
+	return nil
+}
+
+// List implements Storage.
+func (m *MemoryStore) List(identity string) ([]map[string]interface{}, error) {
+	data, err := m.Read(identity)
+	if err != nil {
+		if err == ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var records []map[string]interface{}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Delete implements Storage.
+func (m *MemoryStore) Delete(identity, id string) error {
+	records, err := m.List(identity)
+	if err != nil {
+		return err
+	}
+	filtered, found := removeRecord(records, id)
+	if !found {
+		return ErrRecordNotFound
+	}
+	data, err := json.Marshal(filtered)
+	if err != nil {
+		return err
+	}
+	return m.Write(identity, data)
+}
@@ -0,0 +1,80 @@
+package simdb
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisStore persists every identity's records as a JSON blob under a
+// single Redis key, letting several Driver instances share one database
+// across processes or machines.
+//
+// Unlike the other Storage implementations, RedisStore isn't covered by
+// testStorageContract: it needs a live Redis server, and this repo has
+// no integration-test harness to provide one. Exercise it manually
+// against a real server before relying on a change here.
+type RedisStore struct {
+	client *redis.Client
+	ctx    context.Context
+	prefix string
+}
+
+// NewRedisStore wraps an existing *redis.Client. Keys are namespaced with
+// prefix (e.g. "simdb:") to avoid clashing with other data kept in the
+// same Redis instance.
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	return &RedisStore{client: client, ctx: context.Background(), prefix: prefix}
+}
+
+func (r *RedisStore) key(identity string) string {
+	return r.prefix + identity
+}
+
+// Read implements Storage.
+func (r *RedisStore) Read(identity string) ([]byte, error) {
+	data, err := r.client.Get(r.ctx, r.key(identity)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrRecordNotFound
+	}
+	return data, err
+}
+
+// Write implements Storage.
+func (r *RedisStore) Write(identity string, data []byte) error {
+	return r.client.Set(r.ctx, r.key(identity), data, 0).Err()
+}
+
+// List implements Storage.
+func (r *RedisStore) List(identity string) ([]map[string]interface{}, error) {
+	data, err := r.Read(identity)
+	if err != nil {
+		if err == ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var records []map[string]interface{}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Delete implements Storage.
+func (r *RedisStore) Delete(identity, id string) error {
+	records, err := r.List(identity)
+	if err != nil {
+		return err
+	}
+	filtered, found := removeRecord(records, id)
+	if !found {
+		return ErrRecordNotFound
+	}
+	data, err := json.Marshal(filtered)
+	if err != nil {
+		return err
+	}
+	return r.Write(identity, data)
+}
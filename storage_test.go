@@ -0,0 +1,97 @@
+package simdb
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// testStorageContract exercises the behavior every Storage implementation
+// is expected to provide, independent of its backend.
+func testStorageContract(t *testing.T, newStore func() Storage) {
+	t.Run("ReadMissingReturnsErrRecordNotFound", func(t *testing.T) {
+		s := newStore()
+		if _, err := s.Read("missing"); err != ErrRecordNotFound {
+			t.Fatalf("expected ErrRecordNotFound, got %v", err)
+		}
+	})
+
+	t.Run("ListMissingReturnsEmptyNotError", func(t *testing.T) {
+		s := newStore()
+		records, err := s.List("missing")
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if len(records) != 0 {
+			t.Fatalf("expected no records, got %d", len(records))
+		}
+	})
+
+	t.Run("WriteThenListRoundTrips", func(t *testing.T) {
+		s := newStore()
+		if err := s.Write("customer", []byte(`[{"id":"c1","name":"sarouje"}]`)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		records, err := s.List("customer")
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if len(records) != 1 || records[0]["id"] != "c1" {
+			t.Fatalf("expected 1 record with id c1, got %#v", records)
+		}
+	})
+
+	t.Run("DeleteRemovesOnlyTheMatchingRecord", func(t *testing.T) {
+		s := newStore()
+		if err := s.Write("customer", []byte(`[{"id":"c1"},{"id":"c2"}]`)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		if err := s.Delete("customer", "c1"); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		records, err := s.List("customer")
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if len(records) != 1 || records[0]["id"] != "c2" {
+			t.Fatalf("expected only c2 to remain, got %#v", records)
+		}
+	})
+
+	t.Run("DeleteMissingReturnsErrRecordNotFound", func(t *testing.T) {
+		s := newStore()
+		if err := s.Delete("customer", "missing"); err != ErrRecordNotFound {
+			t.Fatalf("expected ErrRecordNotFound, got %v", err)
+		}
+	})
+}
+
+func TestMemoryStoreContract(t *testing.T) {
+	testStorageContract(t, func() Storage { return NewMemoryStore() })
+}
+
+func TestFileStoreContract(t *testing.T) {
+	root := t.TempDir()
+	n := 0
+	testStorageContract(t, func() Storage {
+		n++
+		store, err := NewFileStore(filepath.Join(root, string(rune('a'+n))))
+		if err != nil {
+			t.Fatalf("NewFileStore: %v", err)
+		}
+		return store
+	})
+}
+
+func TestBoltStoreContract(t *testing.T) {
+	root := t.TempDir()
+	n := 0
+	testStorageContract(t, func() Storage {
+		n++
+		store, err := NewBoltStore(filepath.Join(root, string(rune('a'+n))+".db"), "simdb")
+		if err != nil {
+			t.Fatalf("NewBoltStore: %v", err)
+		}
+		t.Cleanup(func() { store.Close() })
+		return store
+	})
+}
@@ -0,0 +1,212 @@
+package simdb
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// txBuffer holds the pending in-memory state for one identity touched
+// within a transaction: its raw bytes as of the last write, and whether
+// they've changed since the transaction began. Like MemoryStore, it
+// treats the payload as opaque bytes rather than assuming it's always a
+// record array, since schema and index sidecars store non-array JSON
+// documents through the same Storage interface.
+type txBuffer struct {
+	data  []byte
+	dirty bool
+}
+
+// pendingEvent is one mutation event recorded within a transaction,
+// waiting for Commit to dispatch it to the parent Driver's hooks and
+// watchers.
+type pendingEvent struct {
+	identity string
+	evt      Event
+}
+
+// Tx is a transaction over a Driver. It embeds *Driver, so it exposes the
+// same Insert/Update/Upsert/Delete/Open/Where/Get surface, but every
+// write is buffered in memory instead of touching the parent Driver's
+// Storage until Commit is called, and every hook/watcher notification is
+// buffered right along with it so subscribers never see uncommitted data.
+type Tx struct {
+	*Driver
+	parent  *Driver
+	buffers map[string]*txBuffer
+}
+
+// Begin starts a new transaction. An entity is snapshotted from the
+// parent Driver's storage the first time the transaction reads or
+// writes it; none of the transaction's writes are visible to the parent
+// Driver (or to other transactions) until Commit writes them back.
+func (d *Driver) Begin() (*Tx, error) {
+	tx := &Tx{parent: d, buffers: map[string]*txBuffer{}}
+
+	inner := *d
+	inner.storage = &txStorage{tx: tx}
+	pending := []pendingEvent{}
+	inner.pending = &pending
+	tx.Driver = &inner
+
+	return tx, nil
+}
+
+// RunInTx runs fn inside a new transaction, committing if fn returns nil
+// and rolling back if fn returns an error or panics.
+//
+//	err := driver.RunInTx(func(tx *simdb.Tx) error {
+//		if err := tx.Insert(order); err != nil {
+//			return err
+//		}
+//		return tx.Update(customer)
+//	})
+func (d *Driver) RunInTx(fn func(*Tx) error) (err error) {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// buffer returns the transaction's buffer for identity, snapshotting it
+// from the parent Driver's storage on first access.
+func (tx *Tx) buffer(identity string) (*txBuffer, error) {
+	if b, ok := tx.buffers[identity]; ok {
+		return b, nil
+	}
+
+	data, err := tx.parent.storage.Read(identity)
+	if err != nil {
+		if err != ErrRecordNotFound {
+			return nil, err
+		}
+		data = nil
+	}
+	snapshot := make([]byte, len(data))
+	copy(snapshot, data)
+
+	b := &txBuffer{data: snapshot}
+	tx.buffers[identity] = b
+	return b, nil
+}
+
+// Commit writes every identity touched within the transaction back to
+// the parent Driver's storage, holding the parent's mutex for the write
+// phase so a concurrent Insert/Update/Delete on the parent can't
+// interleave with it, then dispatches every event the transaction
+// buffered along the way. Each identity is written on its own; if one
+// write fails, Commit returns that error and the identities already
+// written stay written.
+func (tx *Tx) Commit() error {
+	if tx.buffers == nil {
+		return fmt.Errorf("simdb: transaction already closed")
+	}
+
+	tx.parent.mutex.Lock()
+	defer tx.parent.mutex.Unlock()
+
+	for identity, b := range tx.buffers {
+		if !b.dirty {
+			continue
+		}
+		if err := tx.parent.storage.Write(identity, b.data); err != nil {
+			return err
+		}
+	}
+
+	for _, pe := range *tx.pending {
+		tx.parent.dispatch(pe.identity, pe.evt)
+	}
+
+	tx.buffers = nil
+	tx.pending = nil
+	return nil
+}
+
+// Rollback discards every buffered change made within the transaction,
+// including any events it buffered, none of which are ever dispatched.
+// It's always safe to call, including after Commit, in which case it's
+// a no-op.
+func (tx *Tx) Rollback() {
+	tx.buffers = nil
+	tx.pending = nil
+}
+
+// txStorage is the Storage implementation backing a transaction. Reads
+// are served from the transaction's buffer, snapshotting from the
+// parent Driver's storage on first access; writes and deletes only ever
+// touch the buffer, never the parent. Like MemoryStore, it stores
+// whatever bytes it's given for an identity and only decodes into
+// records in List/Delete, since schema/index sidecars write non-array
+// JSON documents through Write too.
+type txStorage struct {
+	tx *Tx
+}
+
+func (s *txStorage) Read(identity string) ([]byte, error) {
+	b, err := s.tx.buffer(identity)
+	if err != nil {
+		return nil, err
+	}
+	if len(b.data) == 0 {
+		return nil, ErrRecordNotFound
+	}
+	out := make([]byte, len(b.data))
+	copy(out, b.data)
+	return out, nil
+}
+
+func (s *txStorage) Write(identity string, data []byte) error {
+	b, err := s.tx.buffer(identity)
+	if err != nil {
+		return err
+	}
+	stored := make([]byte, len(data))
+	copy(stored, data)
+	b.data = stored
+	b.dirty = true
+	return nil
+}
+
+func (s *txStorage) List(identity string) ([]map[string]interface{}, error) {
+	data, err := s.Read(identity)
+	if err != nil {
+		if err == ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var records []map[string]interface{}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (s *txStorage) Delete(identity, id string) error {
+	records, err := s.List(identity)
+	if err != nil {
+		return err
+	}
+	filtered, found := removeRecord(records, id)
+	if !found {
+		return ErrRecordNotFound
+	}
+	data, err := json.Marshal(filtered)
+	if err != nil {
+		return err
+	}
+	return s.Write(identity, data)
+}
@@ -0,0 +1,122 @@
+package simdb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTxCommitMakesWritesVisibleToParent(t *testing.T) {
+	d := newTestDriver()
+	tx, err := d.Begin()
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if err := tx.Insert(testCustomer{ID: "c1", Name: "sarouje"}); err != nil {
+		t.Fatalf("tx.Insert: %v", err)
+	}
+
+	if err := d.Open(testCustomer{}).Where("id", "=", "c1").First().AsEntity(new(testCustomer)); err == nil {
+		t.Fatalf("expected the uncommitted insert not to be visible on the parent Driver")
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	var out testCustomer
+	if err := d.Open(testCustomer{}).Where("id", "=", "c1").First().AsEntity(&out); err != nil {
+		t.Fatalf("expected the committed insert to be visible on the parent Driver: %v", err)
+	}
+	if out.Name != "sarouje" {
+		t.Fatalf("expected 'sarouje', got %q", out.Name)
+	}
+}
+
+func TestTxRollbackDiscardsWrites(t *testing.T) {
+	d := newTestDriver()
+	tx, err := d.Begin()
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if err := tx.Insert(testCustomer{ID: "c1", Name: "sarouje"}); err != nil {
+		t.Fatalf("tx.Insert: %v", err)
+	}
+	tx.Rollback()
+
+	var out []testCustomer
+	if err := d.Open(testCustomer{}).Get().AsEntity(&out); err == nil {
+		t.Fatalf("expected no records to be visible after Rollback, got %#v", out)
+	}
+}
+
+func TestRunInTxRollsBackOnError(t *testing.T) {
+	d := newTestDriver()
+	wantErr := errors.New("boom")
+
+	err := d.RunInTx(func(tx *Tx) error {
+		if ierr := tx.Insert(testCustomer{ID: "c1", Name: "sarouje"}); ierr != nil {
+			t.Fatalf("tx.Insert: %v", ierr)
+		}
+		return wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("expected RunInTx to return the callback's error, got %v", err)
+	}
+
+	var out []testCustomer
+	if err := d.Open(testCustomer{}).Get().AsEntity(&out); err == nil {
+		t.Fatalf("expected the failed transaction's insert not to be persisted, got %#v", out)
+	}
+}
+
+func TestRunInTxCommitsOnSuccess(t *testing.T) {
+	d := newTestDriver()
+
+	if err := d.RunInTx(func(tx *Tx) error {
+		return tx.Insert(testCustomer{ID: "c1", Name: "sarouje"})
+	}); err != nil {
+		t.Fatalf("RunInTx: %v", err)
+	}
+
+	var out testCustomer
+	if err := d.Open(testCustomer{}).Where("id", "=", "c1").First().AsEntity(&out); err != nil {
+		t.Fatalf("expected the successful transaction's insert to be persisted: %v", err)
+	}
+}
+
+func TestTxInsertWithIndexedEntity(t *testing.T) {
+	d := newTestDriver()
+	if err := d.Insert(testCustomer{ID: "c1", Name: "sarouje"}); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if err := d.EnsureIndex(testCustomer{}, "name", true); err != nil {
+		t.Fatalf("EnsureIndex: %v", err)
+	}
+
+	tx, err := d.Begin()
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if err := tx.Insert(testCustomer{ID: "c2", Name: "someone else"}); err != nil {
+		t.Fatalf("tx.Insert on an indexed entity: %v", err)
+	}
+	if err := tx.Insert(testCustomer{ID: "c3", Name: "sarouje"}); err == nil {
+		t.Fatalf("expected a unique index violation inside the transaction")
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	var out []testCustomer
+	if err := d.Open(testCustomer{}).Get().AsEntity(&out); err != nil {
+		t.Fatalf("AsEntity: %v", err)
+	}
+	if len(out) != 2 {
+		t.Fatalf("expected 2 records (the duplicate must not have been committed), got %d", len(out))
+	}
+
+	var found testCustomer
+	if err := d.Open(testCustomer{}).Where("name", "=", "someone else").First().AsEntity(&found); err != nil {
+		t.Fatalf("expected the index to still resolve a committed record: %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package simdb
+
+import (
+	"fmt"
+	"os"
+)
+
+// IdentifierKey is the record field every Storage implementation and
+// query uses to uniquely identify a record within its identity.
+const IdentifierKey = "id"
+
+// createDirIfNotExist creates dir, along with any missing parents, if it
+// doesn't already exist.
+func createDirIfNotExist(dir string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	return os.MkdirAll(dir, 0755)
+}
+
+// getEntityName returns the string identity name Driver is currently
+// dealing with, set by Open/Insert/Update/Delete.
+func (d *Driver) getEntityName() (string, error) {
+	if d.entityDealingWith.IsEmpty() {
+		return "", fmt.Errorf("simdb: no entity set, call Open/Insert/Update/Delete first")
+	}
+	return d.entityDealingWith.Name, nil
+}
+
+// addError records err so it can later be retrieved via Errors().
+func (d *Driver) addError(err error) {
+	d.errors = append(d.errors, err)
+}
+
+// isDBOpened reports whether Open has been called, recording an error if
+// not.
+func (d *Driver) isDBOpened() bool {
+	if !d.isOpened {
+		d.addError(fmt.Errorf("should call Open() before performing this operation"))
+	}
+	return d.isOpened
+}